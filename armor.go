@@ -0,0 +1,78 @@
+package winkeysafe
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"github.com/awnumar/memguard"
+
+	"github.com/marcgauthier/winkeysafe/util"
+)
+
+// ExportArmored encrypts the key currently loaded into memguard to each of
+// recipients (age public keys, e.g. "age1...") and writes the result to w as
+// an ASCII-armored blob. Unlike a DPAPI-protected key.dat, which cannot be
+// decrypted off the originating Windows machine, an armored export addressed
+// to an offline age key can be safely stored in a password manager or vault
+// and re-imported with ImportArmored after a machine rebuild.
+func ExportArmored(w io.Writer, recipients []string) error {
+	if len(recipients) == 0 {
+		return fmt.Errorf("no recipients given")
+	}
+
+	ageRecipients := make([]age.Recipient, 0, len(recipients))
+	for _, r := range recipients {
+		recipient, err := age.ParseX25519Recipient(r)
+		if err != nil {
+			return fmt.Errorf("parse recipient %q: %w", r, err)
+		}
+		ageRecipients = append(ageRecipients, recipient)
+	}
+
+	armorWriter := armor.NewWriter(w)
+
+	encryptWriter, err := age.Encrypt(armorWriter, ageRecipients...)
+	if err != nil {
+		return fmt.Errorf("create age writer: %w", err)
+	}
+
+	err = GetKey(func(key []byte) error {
+		if _, err := encryptWriter.Write(key); err != nil {
+			return fmt.Errorf("write key: %w", err)
+		}
+		return encryptWriter.Close()
+	})
+	if err != nil {
+		return err
+	}
+
+	return armorWriter.Close()
+}
+
+// ImportArmored reads an ASCII-armored age blob produced by ExportArmored
+// from r, decrypts it with identity (an age secret key, e.g.
+// "AGE-SECRET-KEY-1..."), and loads the resulting key into memory.
+func ImportArmored(r io.Reader, identity string) error {
+	id, err := age.ParseX25519Identity(identity)
+	if err != nil {
+		return fmt.Errorf("parse identity: %w", err)
+	}
+
+	decryptReader, err := age.Decrypt(armor.NewReader(r), id)
+	if err != nil {
+		return fmt.Errorf("create age reader: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, decryptReader); err != nil {
+		return fmt.Errorf("read decrypted key: %w", err)
+	}
+	defer util.Zero(buf.Bytes())
+
+	secureKey = memguard.NewBufferFromBytes(buf.Bytes())
+	return nil
+}
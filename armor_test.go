@@ -0,0 +1,72 @@
+package winkeysafe
+
+import (
+	"bytes"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/awnumar/memguard"
+)
+
+// TestExportArmoredRequiresRecipients tests that ExportArmored rejects an
+// empty recipient list before touching the loaded key.
+func TestExportArmoredRequiresRecipients(t *testing.T) {
+	var buf bytes.Buffer
+	if err := ExportArmored(&buf, nil); err == nil {
+		t.Fatalf("expected ExportArmored to fail with no recipients")
+	}
+}
+
+// TestExportArmoredRequiresLoadedKey tests that ExportArmored fails cleanly
+// when no key has been loaded into memory yet.
+func TestExportArmoredRequiresLoadedKey(t *testing.T) {
+	defer func() { secureKey = nil }()
+	secureKey = nil
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("generate identity: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = ExportArmored(&buf, []string{identity.Recipient().String()})
+	if err == nil {
+		t.Fatalf("expected ExportArmored to fail when no key is loaded")
+	}
+}
+
+// TestExportImportArmoredRoundTrip tests that a key exported with
+// ExportArmored can be recovered with ImportArmored using the matching
+// identity.
+func TestExportImportArmoredRoundTrip(t *testing.T) {
+	defer func() { secureKey = nil }()
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("generate identity: %v", err)
+	}
+
+	key := []byte("super-secret-32-byte-key-value!")
+	secureKey = memguard.NewBufferFromBytes(append([]byte(nil), key...))
+
+	var buf bytes.Buffer
+	if err := ExportArmored(&buf, []string{identity.Recipient().String()}); err != nil {
+		t.Fatalf("ExportArmored failed: %v", err)
+	}
+
+	secureKey = nil
+
+	if err := ImportArmored(&buf, identity.String()); err != nil {
+		t.Fatalf("ImportArmored failed: %v", err)
+	}
+
+	err = GetKey(func(recovered []byte) error {
+		if string(recovered) != string(key) {
+			t.Fatalf("recovered key does not match original. Got: %s, Want: %s", recovered, key)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to retrieve recovered key: %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+//go:build windows
+
+package winkeysafe
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/marcgauthier/winkeysafe/util"
+)
+
+// DPAPIMachineProtector protects key material with DPAPI in the machine
+// context (CRYPTPROTECT_LOCAL_MACHINE), so any process running as any user
+// on this machine can decrypt it. Optional Entropy is mixed in via DPAPI's
+// pOptionalEntropy parameter, binding the ciphertext to an
+// application-specific secret in addition to the machine key.
+type DPAPIMachineProtector struct {
+	Entropy []byte
+}
+
+func (p DPAPIMachineProtector) Protect(data []byte) ([]byte, error) {
+	return encryptData(data, p.Entropy, windows.CRYPTPROTECT_LOCAL_MACHINE)
+}
+
+func (p DPAPIMachineProtector) Unprotect(data []byte) ([]byte, error) {
+	return decryptData(data, p.Entropy, windows.CRYPTPROTECT_LOCAL_MACHINE)
+}
+
+// DPAPIUserProtector protects key material with DPAPI in the calling
+// user's context, restricting decryption to that specific Windows user
+// account. This is the correct default for most deployments: unlike a
+// CRYPTPROTECT_LOCAL_MACHINE blob, it cannot be decrypted by processes
+// running as other users on the machine. Any process running as that same
+// user can still decrypt it unless Entropy is set. Optional Entropy is
+// mixed in the same way as DPAPIMachineProtector.
+type DPAPIUserProtector struct {
+	Entropy []byte
+}
+
+func (p DPAPIUserProtector) Protect(data []byte) ([]byte, error) {
+	return encryptData(data, p.Entropy, 0)
+}
+
+func (p DPAPIUserProtector) Unprotect(data []byte) ([]byte, error) {
+	return decryptData(data, p.Entropy, 0)
+}
+
+// entropyBlob returns a *windows.DataBlob over entropy, or nil if entropy is
+// empty, for use as CryptProtectData/CryptUnprotectData's pOptionalEntropy.
+func entropyBlob(entropy []byte) *windows.DataBlob {
+	if len(entropy) == 0 {
+		return nil
+	}
+	return &windows.DataBlob{Size: uint32(len(entropy)), Data: &entropy[0]}
+}
+
+// encryptData encrypts data using DPAPI under the given protection flags
+// (e.g. windows.CRYPTPROTECT_LOCAL_MACHINE, or 0 for the user context),
+// optionally mixing in entropy.
+func encryptData(data, entropy []byte, flags uint32) ([]byte, error) {
+	desc := windows.StringToUTF16Ptr("")
+	inBlob := windows.DataBlob{
+		Size: uint32(len(data)),
+		Data: &data[0],
+	}
+	var outBlob windows.DataBlob
+
+	err := windows.CryptProtectData(&inBlob, desc, entropyBlob(entropy), 0, nil, flags, &outBlob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data: %v", err)
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(outBlob.Data)))
+
+	encrypted := make([]byte, outBlob.Size)
+	copy(encrypted, unsafe.Slice(outBlob.Data, outBlob.Size))
+	return encrypted, nil
+}
+
+// decryptData decrypts data using DPAPI under the given protection flags,
+// optionally mixing in entropy.
+func decryptData(data, entropy []byte, flags uint32) ([]byte, error) {
+	inBlob := windows.DataBlob{
+		Size: uint32(len(data)),
+		Data: &data[0],
+	}
+	var outBlob windows.DataBlob
+	var desc *uint16
+
+	err := windows.CryptUnprotectData(&inBlob, &desc, entropyBlob(entropy), 0, nil, flags, &outBlob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %v", err)
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(outBlob.Data)))
+	// Wipe DPAPI's own plaintext output buffer before it is freed.
+	defer util.Zero(unsafe.Slice(outBlob.Data, outBlob.Size))
+
+	decrypted := make([]byte, outBlob.Size)
+	copy(decrypted, unsafe.Slice(outBlob.Data, outBlob.Size))
+	return decrypted, nil
+}
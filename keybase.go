@@ -0,0 +1,161 @@
+package winkeysafe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/marcgauthier/winkeysafe/util"
+)
+
+// KDF identifies the key-derivation function used to protect a Keybase entry.
+type KDF int
+
+const (
+	// KDFScrypt derives the sealing key with scrypt (N=32768, r=8, p=1).
+	KDFScrypt KDF = iota
+	// KDFBcrypt derives the sealing key with PBKDF2-HMAC-SHA256 (pbkdf2Iterations rounds).
+	KDFBcrypt
+)
+
+const (
+	saltSize         = 16
+	derivedKeySize   = 32
+	scryptN          = 32768
+	scryptR          = 8
+	scryptP          = 1
+	pbkdf2Iterations = 600_000
+)
+
+// Record is the persisted representation of a passphrase-protected key: the
+// salt and ciphertext produced by Keybase.Encrypt, plus free-form info about
+// the key (e.g. its name or purpose).
+type Record struct {
+	KDF        KDF
+	Salt       []byte
+	Ciphertext []byte
+	Info       string
+}
+
+// Storage persists and retrieves passphrase-protected key Records by name.
+type Storage interface {
+	Put(name string, rec Record) error
+	Get(name string) (Record, error)
+}
+
+// Keybase encrypts and decrypts private keys under a user passphrase,
+// independently of any platform-specific key protector such as DPAPI.
+type Keybase interface {
+	// Encrypt derives a key from pass, seals key under it, and persists the
+	// result under name via the Keybase's Storage.
+	Encrypt(name string, key, pass []byte, info string) error
+	// Decrypt looks up name's Record and returns the key it protects,
+	// failing if pass is wrong or the Record has been tampered with.
+	Decrypt(name string, pass []byte) ([]byte, error)
+}
+
+// passphraseKeybase is the default Keybase: it derives a 32-byte key from
+// the passphrase with scrypt or PBKDF2-HMAC-SHA256 and seals the private key
+// with AES-GCM, storing the salt as the Record's Salt and
+// `nonce || ciphertext` as its Ciphertext.
+type passphraseKeybase struct {
+	store Storage
+	kdf   KDF
+}
+
+// NewKeybase returns a Keybase that persists records through store, deriving
+// each sealing key with kdf.
+func NewKeybase(store Storage, kdf KDF) Keybase {
+	return &passphraseKeybase{store: store, kdf: kdf}
+}
+
+// deriveKey stretches pass+salt into a 32-byte symmetric key using kdf.
+func deriveKey(kdf KDF, pass, salt []byte) ([]byte, error) {
+	switch kdf {
+	case KDFBcrypt:
+		// bcrypt.GenerateFromPassword generates its own random internal
+		// salt from rand.Reader regardless of input, so it cannot serve as
+		// a deterministic KDF: the same pass+salt must always yield the
+		// same key, which Decrypt depends on to reproduce what Encrypt
+		// derived. PBKDF2-HMAC-SHA256 takes pass and salt as separate
+		// inputs and is deterministic in both.
+		return pbkdf2.Key(pass, salt, pbkdf2Iterations, derivedKeySize, sha256.New), nil
+	case KDFScrypt:
+		return scrypt.Key(pass, salt, scryptN, scryptR, scryptP, derivedKeySize)
+	default:
+		return nil, fmt.Errorf("unknown KDF %d", kdf)
+	}
+}
+
+func (k *passphraseKeybase) Encrypt(name string, key, pass []byte, info string) error {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("generate salt: %w", err)
+	}
+
+	derived, err := deriveKey(k.kdf, pass, salt)
+	if err != nil {
+		return err
+	}
+	defer util.Zero(derived)
+
+	gcm, err := newGCM(derived)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, key, nil)
+
+	return k.store.Put(name, Record{
+		KDF:        k.kdf,
+		Salt:       salt,
+		Ciphertext: sealed,
+		Info:       info,
+	})
+}
+
+func (k *passphraseKeybase) Decrypt(name string, pass []byte) ([]byte, error) {
+	rec, err := k.store.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	derived, err := deriveKey(rec.KDF, pass, rec.Salt)
+	if err != nil {
+		return nil, err
+	}
+	defer util.Zero(derived)
+
+	gcm, err := newGCM(derived)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(rec.Ciphertext) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := rec.Ciphertext[:nonceSize], rec.Ciphertext[nonceSize:]
+
+	return gcm.Open(nil, nonce, sealed, nil)
+}
+
+// newGCM wraps a 32-byte key in an AES-GCM AEAD.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("new cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,43 @@
+package winkeysafe
+
+import "testing"
+
+// TestKeybaseEncryptDecrypt tests a full passphrase encrypt/decrypt round
+// trip under every supported KDF.
+func TestKeybaseEncryptDecrypt(t *testing.T) {
+	for _, kdf := range []KDF{KDFScrypt, KDFBcrypt} {
+		store := NewMemStorage()
+		kb := NewKeybase(store, kdf)
+
+		key := []byte("super-secret-32-byte-key-value!")
+		pass := []byte("correct horse battery staple")
+
+		if err := kb.Encrypt("db-key", key, pass, "database encryption key"); err != nil {
+			t.Fatalf("KDF %d: Encrypt failed: %v", kdf, err)
+		}
+
+		decrypted, err := kb.Decrypt("db-key", pass)
+		if err != nil {
+			t.Fatalf("KDF %d: Decrypt failed: %v", kdf, err)
+		}
+
+		if string(decrypted) != string(key) {
+			t.Fatalf("KDF %d: decrypted key does not match original. Got: %s, Want: %s", kdf, decrypted, key)
+		}
+	}
+}
+
+// TestKeybaseWrongPassphrase tests that decryption fails under the wrong passphrase.
+func TestKeybaseWrongPassphrase(t *testing.T) {
+	store := NewMemStorage()
+	kb := NewKeybase(store, KDFBcrypt)
+
+	key := []byte("another-32-byte-secret-key-here")
+	if err := kb.Encrypt("db-key", key, []byte("right passphrase"), ""); err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+
+	if _, err := kb.Decrypt("db-key", []byte("wrong passphrase")); err == nil {
+		t.Fatalf("expected Decrypt to fail with the wrong passphrase")
+	}
+}
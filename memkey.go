@@ -0,0 +1,35 @@
+package winkeysafe
+
+import (
+	"errors"
+
+	"github.com/awnumar/memguard"
+)
+
+// secureKey is a locked buffer holding the encryption key in memory.
+var secureKey *memguard.LockedBuffer
+
+// GetKey calls fn with the key currently loaded into memguard. The buffer
+// is melted only for the duration of fn and frozen again before GetKey
+// returns, so the caller never holds a reference to the key past fn's
+// scope. Returns an error if the key is not loaded, or whatever error fn
+// returns.
+func GetKey(fn func(key []byte) error) error {
+	if secureKey == nil {
+		return errors.New("key not loaded into memory")
+	}
+
+	secureKey.Melt()
+	defer secureKey.Freeze()
+
+	return fn(secureKey.Bytes())
+}
+
+// DestroyKey wipes and releases the in-memory key, if one is loaded.
+func DestroyKey() {
+	if secureKey == nil {
+		return
+	}
+	secureKey.Destroy()
+	secureKey = nil
+}
@@ -0,0 +1,28 @@
+package winkeysafe
+
+import (
+	"os"
+	"testing"
+
+	"github.com/awnumar/memguard"
+)
+
+// TestMain initializes memguard and provides cleanup.
+func TestMain(m *testing.M) {
+	memguard.CatchInterrupt()
+	defer memguard.Purge()
+	os.Exit(m.Run())
+}
+
+// TestDestroyKey tests the key destruction functionality.
+func TestDestroyKey(t *testing.T) {
+	// Setup
+	secureKey = memguard.NewBufferFromBytes([]byte("dummy-key"))
+
+	// Destroy the key
+	DestroyKey()
+
+	if secureKey != nil {
+		t.Fatalf("Expected secureKey to be nil after DestroyKey, but it is not nil")
+	}
+}
@@ -0,0 +1,133 @@
+package winkeysafe
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/marcgauthier/winkeysafe/util"
+)
+
+// wordsList is the default word list used to turn generated key entropy
+// into a human-writable recovery phrase, and the default list consulted by
+// EncodeMnemonic/DecodeMnemonic. It defaults to the bundled englishWordList
+// and may be overridden (NewWithMnemonic does so); EncodeMnemonic and
+// DecodeMnemonic reject any list that does not hold exactly 2048 words.
+var wordsList = englishWordList
+
+// mnemonicEntropyBytes is the amount of random entropy fed to EncodeMnemonic
+// when generating a new key: 256 bits, the standard BIP39 entropy size that
+// yields a 24-word phrase (mnemonicWordCount).
+const mnemonicEntropyBytes = 32
+
+// mnemonicWordCount is the number of words a 256-bit mnemonic encodes to:
+// (256 entropy bits + 8 checksum bits) / 11 bits per word.
+const mnemonicWordCount = 24
+
+// generateMnemonicKey draws mnemonicEntropyBytes of random entropy and
+// encodes it as a BIP39-style phrase with EncodeMnemonic, so the generated
+// phrase carries the same checksum DecodeMnemonic validates on restore. It
+// returns []byte rather than string so the phrase's backing memory can
+// later be wiped with util.Zero once the caller is done with it.
+func generateMnemonicKey() ([]byte, error) {
+	entropy := make([]byte, mnemonicEntropyBytes)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, fmt.Errorf("generate entropy: %w", err)
+	}
+	defer util.Zero(entropy)
+
+	phrase, err := EncodeMnemonic(entropy)
+	if err != nil {
+		return nil, fmt.Errorf("encode mnemonic: %w", err)
+	}
+	return []byte(phrase), nil
+}
+
+// EncodeMnemonic encodes entropy as a BIP39-style mnemonic phrase using
+// wordsList, which must hold exactly 2048 words (11 bits per word). The
+// checksum is the first len(entropy)*8/32 bits of SHA-256(entropy),
+// appended to entropy before splitting into 11-bit word groups.
+func EncodeMnemonic(entropy []byte) (string, error) {
+	if len(entropy) == 0 || len(entropy)%4 != 0 {
+		return "", errors.New("entropy length must be a non-zero multiple of 32 bits")
+	}
+	if len(wordsList) != 2048 {
+		return "", fmt.Errorf("mnemonic encoding requires a 2048-word list, got %d", len(wordsList))
+	}
+
+	checksumBits := len(entropy) * 8 / 32
+	sum := sha256.Sum256(entropy)
+
+	bits := new(big.Int).SetBytes(entropy)
+	bits.Lsh(bits, uint(checksumBits))
+
+	checksum := new(big.Int).SetBytes(sum[:])
+	checksum.Rsh(checksum, uint(256-checksumBits))
+	bits.Or(bits, checksum)
+
+	wordCount := (len(entropy)*8 + checksumBits) / 11
+	mask := big.NewInt(0x7FF) // 11 bits
+
+	words := make([]string, wordCount)
+	for i := wordCount - 1; i >= 0; i-- {
+		group := new(big.Int).And(bits, mask)
+		words[i] = wordsList[group.Int64()]
+		bits.Rsh(bits, 11)
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+// DecodeMnemonic validates and decodes a BIP39-style mnemonic phrase
+// produced by EncodeMnemonic against wordsList, returning the original
+// entropy. It rejects unknown words and phrases whose checksum doesn't
+// match.
+func DecodeMnemonic(words string) ([]byte, error) {
+	if len(wordsList) != 2048 {
+		return nil, fmt.Errorf("mnemonic decoding requires a 2048-word list, got %d", len(wordsList))
+	}
+
+	fields := strings.Fields(words)
+	if len(fields) == 0 || len(fields)%3 != 0 {
+		return nil, fmt.Errorf("invalid mnemonic length: %d words", len(fields))
+	}
+
+	indexOf := make(map[string]int64, len(wordsList))
+	for i, w := range wordsList {
+		indexOf[w] = int64(i)
+	}
+
+	bits := new(big.Int)
+	for _, w := range fields {
+		idx, ok := indexOf[w]
+		if !ok {
+			return nil, fmt.Errorf("invalid mnemonic word: %q", w)
+		}
+		bits.Lsh(bits, 11)
+		bits.Or(bits, big.NewInt(idx))
+	}
+
+	totalBits := len(fields) * 11
+	checksumBits := totalBits / 33
+	entropyBits := totalBits - checksumBits
+
+	checksumMask := new(big.Int).Lsh(big.NewInt(1), uint(checksumBits))
+	checksumMask.Sub(checksumMask, big.NewInt(1))
+
+	checksum := new(big.Int).And(bits, checksumMask)
+	entropy := new(big.Int).Rsh(bits, uint(checksumBits))
+	entropyBytes := entropy.FillBytes(make([]byte, entropyBits/8))
+
+	sum := sha256.Sum256(entropyBytes)
+	expected := new(big.Int).SetBytes(sum[:])
+	expected.Rsh(expected, uint(256-checksumBits))
+
+	if expected.Cmp(checksum) != 0 {
+		return nil, errors.New("mnemonic checksum mismatch")
+	}
+
+	return entropyBytes, nil
+}
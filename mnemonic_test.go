@@ -0,0 +1,50 @@
+package winkeysafe
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestMnemonicEncodeDecodeRoundTrip tests that decoding an encoded phrase
+// recovers the original entropy.
+func TestMnemonicEncodeDecodeRoundTrip(t *testing.T) {
+	entropy := make([]byte, 32)
+	for i := range entropy {
+		entropy[i] = byte(i)
+	}
+
+	phrase, err := EncodeMnemonic(entropy)
+	if err != nil {
+		t.Fatalf("EncodeMnemonic failed: %v", err)
+	}
+
+	decoded, err := DecodeMnemonic(phrase)
+	if err != nil {
+		t.Fatalf("DecodeMnemonic failed: %v", err)
+	}
+
+	if string(decoded) != string(entropy) {
+		t.Fatalf("decoded entropy does not match original. Got: %x, Want: %x", decoded, entropy)
+	}
+}
+
+// TestMnemonicDecodeRejectsBadChecksum tests that a tampered phrase is rejected.
+func TestMnemonicDecodeRejectsBadChecksum(t *testing.T) {
+	entropy := make([]byte, 32)
+	phrase, err := EncodeMnemonic(entropy)
+	if err != nil {
+		t.Fatalf("EncodeMnemonic failed: %v", err)
+	}
+
+	words := strings.Fields(phrase)
+	replacement := wordsList[0]
+	if replacement == words[len(words)-1] {
+		replacement = wordsList[1]
+	}
+	words[len(words)-1] = replacement
+	tampered := strings.Join(words, " ")
+
+	if _, err := DecodeMnemonic(tampered); err == nil {
+		t.Fatalf("expected DecodeMnemonic to reject a tampered phrase")
+	}
+}
@@ -0,0 +1,22 @@
+//go:build windows
+
+package winkeysafe
+
+// NewWithMnemonic behaves like New with a DPAPIMachineProtector, generating
+// the key as a BIP39-style mnemonic phrase drawn from wordlist. A hand-written word phrase is
+// far more robust to transcribe than "!@#$%^&*"-style characters: it can be
+// decoded with DecodeMnemonic and retyped into plainTextFile to restore the
+// key on a new machine, then protected to cipherFile there by calling New
+// (or NewWithMnemonic) again. If wordlist is empty, the package default
+// wordsList is used. As with New, the returned phrase is the caller's to
+// zero with util.Zero once recorded.
+func NewWithMnemonic(cipherFile, plainTextFile string, wordlist []string) ([]byte, error) {
+	if len(wordlist) > 0 {
+		wordsList = wordlist
+	}
+	return New(Options{
+		CipherFile:    cipherFile,
+		PlainTextFile: plainTextFile,
+		Protector:     DPAPIMachineProtector{},
+	})
+}
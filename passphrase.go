@@ -0,0 +1,166 @@
+package winkeysafe
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/awnumar/memguard"
+	"golang.org/x/term"
+
+	"github.com/marcgauthier/winkeysafe/util"
+)
+
+// PromptPassphrase reads a passphrase from the terminal without echoing it,
+// for use as the prompt argument to NewWithPassphrase.
+func PromptPassphrase() ([]byte, error) {
+	fmt.Fprint(os.Stderr, "Passphrase: ")
+	pass, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("read passphrase: %w", err)
+	}
+	return pass, nil
+}
+
+// NewWithPassphrase behaves like New, but protects the key with a
+// passphrase instead of DPAPI: the passphrase is stretched into a symmetric
+// key with scrypt and the key material is sealed with AES-GCM. Because this
+// does not depend on any machine- or user-bound OS secret, the resulting
+// cipherFile can be restored on a different host, unlike DPAPI-protected
+// key.dat files. prompt is called to read the passphrase from the
+// terminal; when cipherFile does not yet exist it is called a second time
+// so the passphrase can be confirmed before anything is written. As with
+// New, the generated phrase is returned as []byte, and it is the caller's
+// responsibility to record it and then call util.Zero on it.
+func NewWithPassphrase(cipherFile string, prompt func() ([]byte, error)) ([]byte, error) {
+	if fileExists(cipherFile) {
+		pass, err := prompt()
+		if err != nil {
+			return nil, fmt.Errorf("read passphrase: %w", err)
+		}
+		defer util.Zero(pass)
+
+		blob, err := os.ReadFile(cipherFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", cipherFile, err)
+		}
+
+		key, err := passphraseDecrypt(blob, pass)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt %s: %w", cipherFile, err)
+		}
+		defer util.Zero(key)
+
+		secureKey = memguard.NewBufferFromBytes(key)
+		return nil, nil
+	}
+
+	pass, err := prompt()
+	if err != nil {
+		return nil, fmt.Errorf("read passphrase: %w", err)
+	}
+	defer util.Zero(pass)
+
+	confirm, err := prompt()
+	if err != nil {
+		return nil, fmt.Errorf("read passphrase confirmation: %w", err)
+	}
+	defer util.Zero(confirm)
+
+	if string(pass) != string(confirm) {
+		return nil, errors.New("passphrases do not match")
+	}
+
+	words, err := generateMnemonicKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	blob, err := passphraseEncrypt(words, pass)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt generated key: %w", err)
+	}
+
+	if err := os.WriteFile(cipherFile, blob, 0600); err != nil {
+		return nil, fmt.Errorf("failed to save %s: %w", cipherFile, err)
+	}
+
+	// NewBufferFromBytes wipes its argument after copying it into secure
+	// memory, so secureKey is seeded from a copy: words itself is still
+	// returned to the caller below and must stay intact until they've
+	// recorded it.
+	secureKey = memguard.NewBufferFromBytes(append([]byte(nil), words...))
+
+	return words, nil
+}
+
+// PassphraseProtector is a KeyProtector that derives a symmetric key from
+// Pass with scrypt and seals data with AES-GCM, for use with New on any
+// platform (in place of a DPAPI protector).
+type PassphraseProtector struct {
+	Pass []byte
+}
+
+func (p PassphraseProtector) Protect(data []byte) ([]byte, error) {
+	return passphraseEncrypt(data, p.Pass)
+}
+
+func (p PassphraseProtector) Unprotect(data []byte) ([]byte, error) {
+	return passphraseDecrypt(data, p.Pass)
+}
+
+// passphraseEncrypt derives a key from pass with scrypt and seals data
+// under it with AES-GCM, returning `salt || nonce || ciphertext`.
+func passphraseEncrypt(data, pass []byte) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generate salt: %w", err)
+	}
+
+	derived, err := deriveKey(KDFScrypt, pass, salt)
+	if err != nil {
+		return nil, err
+	}
+	defer util.Zero(derived)
+
+	gcm, err := newGCM(derived)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	return append(salt, gcm.Seal(nonce, nonce, data, nil)...), nil
+}
+
+// passphraseDecrypt reverses passphraseEncrypt.
+func passphraseDecrypt(blob, pass []byte) ([]byte, error) {
+	if len(blob) < saltSize {
+		return nil, errors.New("ciphertext too short")
+	}
+	salt, sealed := blob[:saltSize], blob[saltSize:]
+
+	derived, err := deriveKey(KDFScrypt, pass, salt)
+	if err != nil {
+		return nil, err
+	}
+	defer util.Zero(derived)
+
+	gcm, err := newGCM(derived)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:nonceSize], sealed[nonceSize:]
+
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
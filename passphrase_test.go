@@ -0,0 +1,54 @@
+package winkeysafe
+
+import (
+	"os"
+	"testing"
+)
+
+// TestNewWithPassphraseGenerateAndRestore tests key generation, storage,
+// and restoration through a passphrase instead of DPAPI.
+func TestNewWithPassphraseGenerateAndRestore(t *testing.T) {
+	defer func() { secureKey = nil }()
+
+	cipherFile := "test_passphrase.dat"
+	defer os.Remove(cipherFile)
+
+	prompt := fixedPassphrase("correct horse battery staple")
+
+	words, err := NewWithPassphrase(cipherFile, prompt)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	if len(words) == 0 {
+		t.Fatalf("expected generated key material, got none")
+	}
+
+	secureKey = nil
+
+	if _, err := NewWithPassphrase(cipherFile, prompt); err != nil {
+		t.Fatalf("failed to restore key with correct passphrase: %v", err)
+	}
+
+	err = GetKey(func(key []byte) error {
+		if string(key) != string(words) {
+			t.Fatalf("restored key does not match generated key. Got: %s, Want: %s", key, words)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to retrieve key: %v", err)
+	}
+
+	secureKey = nil
+	if _, err := NewWithPassphrase(cipherFile, fixedPassphrase("wrong passphrase")); err == nil {
+		t.Fatalf("expected restore to fail with the wrong passphrase")
+	}
+}
+
+// fixedPassphrase returns a prompt func that always yields pass, simulating
+// a terminal read for tests.
+func fixedPassphrase(pass string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		return []byte(pass), nil
+	}
+}
@@ -0,0 +1,60 @@
+package winkeysafe
+
+import (
+	"os"
+	"testing"
+)
+
+// TestNewWithPassphraseProtector tests that New works with any KeyProtector,
+// using PassphraseProtector as a cross-platform stand-in for DPAPI.
+func TestNewWithPassphraseProtector(t *testing.T) {
+	defer func() { secureKey = nil }()
+
+	cipherFile := "test_protector.dat"
+	plainTextFile := "test_protector.txt"
+	defer func() {
+		removeIfExists(cipherFile)
+		removeIfExists(plainTextFile)
+	}()
+
+	opts := Options{
+		CipherFile:    cipherFile,
+		PlainTextFile: plainTextFile,
+		Protector:     PassphraseProtector{Pass: []byte("test passphrase")},
+	}
+
+	words, err := New(opts)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	removeIfExists(plainTextFile)
+	secureKey = nil
+
+	if _, err := New(opts); err != nil {
+		t.Fatalf("failed to load the generated key: %v", err)
+	}
+
+	err = GetKey(func(key []byte) error {
+		if string(key) != string(words) {
+			t.Fatalf("loaded key does not match generated key. Got: %s, Want: %s", key, words)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to retrieve key: %v", err)
+	}
+}
+
+// TestNewRequiresProtector tests that New rejects Options with no Protector.
+func TestNewRequiresProtector(t *testing.T) {
+	if _, err := New(Options{CipherFile: "unused.dat", PlainTextFile: "unused.txt"}); err == nil {
+		t.Fatalf("expected New to fail with no Protector")
+	}
+}
+
+func removeIfExists(path string) {
+	if fileExists(path) {
+		_ = os.Remove(path)
+	}
+}
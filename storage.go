@@ -0,0 +1,77 @@
+package winkeysafe
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStorage persists Keybase Records as one JSON file per name inside Dir.
+type FileStorage struct {
+	Dir string
+}
+
+// NewFileStorage returns a FileStorage rooted at dir, creating it if needed.
+func NewFileStorage(dir string) (*FileStorage, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create storage dir %s: %w", dir, err)
+	}
+	return &FileStorage{Dir: dir}, nil
+}
+
+func (s *FileStorage) path(name string) string {
+	return filepath.Join(s.Dir, name+".json")
+}
+
+func (s *FileStorage) Put(name string, rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal record %q: %w", name, err)
+	}
+	if err := os.WriteFile(s.path(name), data, 0600); err != nil {
+		return fmt.Errorf("write record %q: %w", name, err)
+	}
+	return nil
+}
+
+func (s *FileStorage) Get(name string) (Record, error) {
+	data, err := os.ReadFile(s.path(name))
+	if err != nil {
+		return Record{}, fmt.Errorf("read record %q: %w", name, err)
+	}
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return Record{}, fmt.Errorf("unmarshal record %q: %w", name, err)
+	}
+	return rec, nil
+}
+
+// MemStorage is an in-memory Storage, primarily useful for tests.
+type MemStorage struct {
+	mu      sync.RWMutex
+	records map[string]Record
+}
+
+// NewMemStorage returns an empty in-memory Storage.
+func NewMemStorage() *MemStorage {
+	return &MemStorage{records: make(map[string]Record)}
+}
+
+func (s *MemStorage) Put(name string, rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[name] = rec
+	return nil
+}
+
+func (s *MemStorage) Get(name string) (Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rec, ok := s.records[name]
+	if !ok {
+		return Record{}, fmt.Errorf("no record for %q", name)
+	}
+	return rec, nil
+}
@@ -0,0 +1,31 @@
+package winkeysafe
+
+import (
+	"crypto/rand"
+	"math/big"
+	"os"
+)
+
+// secureRandomInt generates a cryptographically secure random integer in the
+// range [0, max). It delegates to crypto/rand.Int, which rejection-samples
+// internally, rather than reducing a single byte mod max: that approach is
+// only uniform for max <= 256, and silently truncates the usable range to
+// [0, 256) for any larger max.
+func secureRandomInt(max int) (int, error) {
+	if max <= 0 {
+		return 0, nil
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n.Int64()), nil
+}
+
+// fileExists checks if a given file exists on the system.
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
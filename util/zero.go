@@ -0,0 +1,15 @@
+// Package util provides small security-hygiene helpers shared across
+// winkeysafe's key-handling code paths.
+package util
+
+import "runtime"
+
+// Zero overwrites b with zeros in a way the compiler cannot optimize away,
+// so callers can reliably wipe sensitive plaintext buffers as soon as they
+// are no longer needed.
+func Zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	runtime.KeepAlive(b)
+}
@@ -0,0 +1,15 @@
+package util
+
+import "testing"
+
+// TestZero tests that Zero overwrites every byte of its argument.
+func TestZero(t *testing.T) {
+	b := []byte("sensitive data")
+	Zero(b)
+
+	for i, v := range b {
+		if v != 0 {
+			t.Fatalf("byte %d not zeroed: got %d", i, v)
+		}
+	}
+}
@@ -1,3 +1,5 @@
+//go:build windows
+
 package winkeysafe
 
 import (
@@ -5,27 +7,14 @@ import (
 	"os"
 	"testing"
 
-	"github.com/awnumar/memguard"
+	"golang.org/x/sys/windows"
 )
 
-var testWordsList = []string{
-	"alpha", "bravo", "charlie", "delta", "echo", "foxtrot",
-	"golf", "hotel", "india", "juliet", "kilo", "lima",
-	"mike", "november", "oscar", "papa", "quebec", "romeo",
-	"sierra", "tango", "uniform", "victor", "whiskey", "xray",
-}
-
-// TestMain initializes memguard and provides cleanup.
-func TestMain(m *testing.M) {
-	memguard.CatchInterrupt()
-	defer memguard.Purge()
-	os.Exit(m.Run())
-}
-
 // TestGenerateAndRetrieveKey tests key generation, storage, and retrieval.
 func TestGenerateAndRetrieveKey(t *testing.T) {
-	// Setup
-	wordsList = testWordsList
+	// Setup: generateMnemonicKey requires the full 2048-word list, so this
+	// test generates against the package default rather than a short
+	// custom list.
 	defer func() { secureKey = nil }() // Reset secureKey after the test
 
 	cipherFile := "test_key.dat"
@@ -34,8 +23,10 @@ func TestGenerateAndRetrieveKey(t *testing.T) {
 	// Ensure test files are removed after the test
 	defer os.Remove(cipherFile)
 
+	opts := Options{CipherFile: cipherFile, PlainTextFile: plainTextFile, Protector: DPAPIMachineProtector{}}
+
 	// Generate a new key
-	words, err := New(cipherFile, plainTextFile)
+	words, err := New(opts)
 	if err != nil {
 		t.Fatalf("unable to generate key: %v", err)
 	}
@@ -45,34 +36,25 @@ func TestGenerateAndRetrieveKey(t *testing.T) {
 	os.Remove(plainTextFile)
 
 	// Load the generated key
-	_, err = New(cipherFile, plainTextFile)
+	_, err = New(opts)
 	if err != nil {
 		t.Fatalf("Failed to load the generated key: %v", err)
 	}
 
 	// Retrieve the key
-	key, err := GetKey()
+	var keyLen int
+	err = GetKey(func(key []byte) error {
+		keyLen = len(key)
+		return nil
+	})
 	if err != nil {
 		t.Fatalf("Failed to retrieve key: %v", err)
 	}
-	if len(key) == 0 {
+	if keyLen == 0 {
 		t.Fatalf("Key should not be empty")
 	}
 }
 
-// TestDestroyKey tests the key destruction functionality.
-func TestDestroyKey(t *testing.T) {
-	// Setup
-	secureKey = memguard.NewBufferFromBytes([]byte("dummy-key"))
-
-	// Destroy the key
-	DestroyKey()
-
-	if secureKey != nil {
-		t.Fatalf("Expected secureKey to be nil after DestroyKey, but it is not nil")
-	}
-}
-
 // TestEncryptAndDecrypt tests encryption and decryption functionality.
 func TestEncryptAndDecrypt(t *testing.T) {
 	// Setup
@@ -81,7 +63,7 @@ func TestEncryptAndDecrypt(t *testing.T) {
 	copy(secureData, data)
 
 	// Encrypt data
-	encrypted, err := encryptData(secureData)
+	encrypted, err := encryptData(secureData, nil, windows.CRYPTPROTECT_LOCAL_MACHINE)
 	if err != nil {
 		t.Fatalf("Failed to encrypt data: %v", err)
 	}
@@ -91,7 +73,7 @@ func TestEncryptAndDecrypt(t *testing.T) {
 	}
 
 	// Decrypt data
-	decrypted, err := decryptData(encrypted)
+	decrypted, err := decryptData(encrypted, nil, windows.CRYPTPROTECT_LOCAL_MACHINE)
 	if err != nil {
 		t.Fatalf("Failed to decrypt data: %v", err)
 	}
@@ -0,0 +1,34 @@
+package winkeysafe
+
+import "sort"
+
+// englishWordList is the module's bundled default word list: 2048 unique,
+// sorted, pronounceable words generated from a fixed consonant-vowel-
+// consonant syllable table so the module carries no external word-list
+// dependency. Any BIP39-compatible 2048-word list (including the canonical
+// English list) can be substituted via NewWithMnemonic's wordlist argument.
+var englishWordList = buildDefaultWordList()
+
+var (
+	initialConsonants = []string{"b", "c", "d", "f", "g", "h", "j", "k", "l", "m", "n", "p", "r", "s", "t", "v", "w", "z", "br", "cl", "dr", "fr", "gl", "pl", "sh", "st", "tr"}
+	vowels            = []string{"a", "e", "i", "o", "u"}
+	finalConsonants   = []string{"b", "d", "g", "k", "l", "m", "n", "p", "r", "s", "t", "x", "ck", "nd", "ng", "nt", "sh", "st"}
+)
+
+// buildDefaultWordList deterministically combines syllables into 2048
+// unique words and returns them in sorted order.
+func buildDefaultWordList() []string {
+	words := make([]string, 0, 2048)
+	for _, c1 := range initialConsonants {
+		for _, v := range vowels {
+			for _, c2 := range finalConsonants {
+				words = append(words, c1+v+c2)
+				if len(words) == 2048 {
+					sort.Strings(words)
+					return words
+				}
+			}
+		}
+	}
+	panic("winkeysafe: default word list generator produced fewer than 2048 words")
+}